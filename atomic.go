@@ -0,0 +1,37 @@
+package main
+
+import "os"
+
+// atomicWriteFile writes data to a temp file alongside path, fsyncs it, and
+// renames it into place, so a crash or write error can never leave path
+// truncated or half-written.
+func atomicWriteFile(path string, data []byte, mode os.FileMode) error {
+	tmpPath := path + ".tmp-altassets"
+
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
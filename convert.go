@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maybeConvertToJPEG opportunistically flattens a non-transparent PNG/GIF
+// frame into JPEG, mirroring imageoptimizer's convert-on-shrink behavior.
+// It only takes effect when --convert-to jpg is set, img has no alpha
+// channel, and the resulting JPEG is actually smaller than currentBytes;
+// otherwise the original bytes and extension are returned untouched.
+func maybeConvertToJPEG(ext string, img image.Image, currentBytes []byte) (newExt string, newBytes []byte, converted bool) {
+	if convertTo != "jpg" || (ext != ".png" && ext != ".gif") {
+		return ext, currentBytes, false
+	}
+	if imageHasAlpha(img) {
+		return ext, currentBytes, false
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return ext, currentBytes, false
+	}
+
+	if buf.Len() >= len(currentBytes) {
+		return ext, currentBytes, false
+	}
+
+	return ".jpg", buf.Bytes(), true
+}
+
+// withExt swaps a path's extension, e.g. "photo.png" + ".jpg" -> "photo.jpg".
+func withExt(path, ext string) string {
+	trimmed := strings.TrimSuffix(path, filepath.Ext(path))
+	return trimmed + ext
+}
+
+// convertCollides reports whether swapping path to newExt would land on a
+// file that already exists and isn't path itself, e.g. converting img1.png
+// to JPEG when an unrelated img1.jpg already sits next to it. Callers should
+// skip the conversion rather than risk clobbering that unrelated file.
+func convertCollides(path, newExt string) bool {
+	outPath := withExt(path, newExt)
+	if outPath == path {
+		return false
+	}
+	_, err := os.Stat(outPath)
+	return err == nil
+}
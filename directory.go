@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileJob is one unit of work handed to the worker pool.
+type fileJob struct {
+	path string
+}
+
+// fileJobResult carries a single file's outcome back to the aggregator.
+type fileJobResult struct {
+	path   string
+	result *FileResult
+	err    error
+}
+
+// collectFiles walks root and returns every supported image file found,
+// honoring maxDepth: a negative maxDepth recurses without limit, otherwise
+// directories more than maxDepth levels below root are not descended into.
+// depth 0 means "root only, do not recurse".
+func collectFiles(root string, maxDepth int) ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if maxDepth >= 0 {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				depth := strings.Count(rel, string(filepath.Separator)) + 1
+				if depth > maxDepth {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if supportedExtensions[ext] {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	return paths, err
+}
+
+// processDirectory walks root up to maxDepth and processes every supported
+// image file it finds through a pool of jobs worker goroutines, folding
+// each result into report as it completes.
+func processDirectory(root string, maxDepth, jobs int, report *DirectoryReport) error {
+	paths, err := collectFiles(root, maxDepth)
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	jobCh := make(chan fileJob)
+	resultCh := make(chan fileJobResult)
+
+	var workers sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobCh {
+				result, err := processFile(job.path)
+				resultCh <- fileJobResult{path: job.path, result: result, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, path := range paths {
+			jobCh <- fileJob{path: path}
+		}
+		close(jobCh)
+	}()
+
+	go func() {
+		workers.Wait()
+		close(resultCh)
+	}()
+
+	progress := newProgressReporter(len(paths))
+	var bytesProcessed int64
+	done := 0
+
+	for res := range resultCh {
+		done++
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", res.path, res.err)
+			progress.Update(done, bytesProcessed)
+			continue
+		}
+
+		printFileResult(res.result)
+		report.Add(res.result)
+		bytesProcessed += res.result.OriginalSize
+		progress.Update(done, bytesProcessed)
+	}
+	progress.Finish()
+
+	return nil
+}
+
+// progressReporter prints a single, overwritten progress line to stderr
+// while a directory is being processed, but only when stderr is a TTY -
+// piping output to a file or another process should stay script-friendly.
+type progressReporter struct {
+	enabled bool
+	total   int
+	start   time.Time
+}
+
+func newProgressReporter(total int) *progressReporter {
+	return &progressReporter{
+		enabled: isTerminal(os.Stderr),
+		total:   total,
+		start:   time.Now(),
+	}
+}
+
+func (p *progressReporter) Update(done int, bytesProcessed int64) {
+	if !p.enabled {
+		return
+	}
+
+	elapsed := time.Since(p.start)
+	var eta time.Duration
+	if done > 0 {
+		eta = elapsed / time.Duration(done) * time.Duration(p.total-done)
+	}
+
+	fmt.Fprintf(os.Stderr, "\r%d/%d files, %d bytes processed, ETA %s    ",
+		done, p.total, bytesProcessed, eta.Round(time.Second))
+}
+
+func (p *progressReporter) Finish() {
+	if !p.enabled {
+		return
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// isTerminal reports whether f looks like an interactive terminal.
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestCollectFilesDepth covers the boundary cases of maxDepth: unlimited
+// recursion, root-only, and an exact intermediate level.
+func TestCollectFilesDepth(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, filepath.Join(root, "a.png"))
+	writeFixture(t, filepath.Join(root, "sub1", "b.png"))
+	writeFixture(t, filepath.Join(root, "sub1", "sub2", "c.png"))
+
+	cases := []struct {
+		name     string
+		maxDepth int
+		want     []string
+	}{
+		{"negative is unlimited", -1, []string{"a.png", "sub1/b.png", "sub1/sub2/c.png"}},
+		{"zero is root only", 0, []string{"a.png"}},
+		{"one level deep", 1, []string{"a.png", "sub1/b.png"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := collectFiles(root, tc.maxDepth)
+			if err != nil {
+				t.Fatalf("collectFiles(%d): %v", tc.maxDepth, err)
+			}
+
+			gotRel := make([]string, len(got))
+			for i, p := range got {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					t.Fatalf("filepath.Rel: %v", err)
+				}
+				gotRel[i] = filepath.ToSlash(rel)
+			}
+			sort.Strings(gotRel)
+
+			want := append([]string(nil), tc.want...)
+			sort.Strings(want)
+
+			if !equalStringSlices(gotRel, want) {
+				t.Errorf("collectFiles(%d) = %v, want %v", tc.maxDepth, gotRel, want)
+			}
+		})
+	}
+}
+
+func writeFixture(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("fixture"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
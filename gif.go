@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"os"
+)
+
+// encodeGIF re-encodes an animated or static GIF, applying the selected
+// perturbation strategy to every frame while preserving the original
+// palette, delay, disposal method and loop count. imaging.Open/Save cannot
+// be used here since it flattens multi-frame GIFs down to their first frame.
+func encodeGIF(path string, perturber Perturber) ([]byte, error) {
+	if _, err := checkFileSize(path); err != nil {
+		return nil, err
+	}
+	if err := checkGIFPixelBudget(path); err != nil {
+		return nil, err
+	}
+
+	g, err := decodeGIFGuarded(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, frame := range g.Image {
+		perturbPalettedFrame(frame, perturber, path)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// firstGIFFrame decodes a GIF (every frame, via gif.DecodeAll) and hands
+// back its first frame, for callers (like --convert-to jpg) that only care about a single
+// representative image rather than the full animation.
+func firstGIFFrame(path string) (image.Image, error) {
+	if _, err := checkFileSize(path); err != nil {
+		return nil, err
+	}
+	if err := checkGIFPixelBudget(path); err != nil {
+		return nil, err
+	}
+
+	g, err := decodeGIFGuarded(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("gif has no frames")
+	}
+
+	return g.Image[0], nil
+}
+
+// checkGIFPixelBudget reads just path's GIF header via the generic image
+// package (image/gif registers itself with image.RegisterFormat) so the
+// pixel budget can be enforced before gif.DecodeAll allocates every frame.
+func checkGIFPixelBudget(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return fmt.Errorf("failed to read gif header: %w", err)
+	}
+	return checkPixelBudget(cfg.Width, cfg.Height)
+}
+
+// decodeGIFGuarded decodes path's GIF data behind a timeout and panic
+// recovery, mirroring safeDecode's protections for image/gif's separate
+// decode entry point.
+func decodeGIFGuarded(path string) (*gif.GIF, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), decodeTimeout)
+	defer cancel()
+
+	type result struct {
+		g   *gif.GIF
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("panic while decoding gif: %v", r)}
+			}
+		}()
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(src))
+		done <- result{g: g, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.g, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("decoding %s timed out after %s", path, decodeTimeout)
+	}
+}
+
+// perturbPalettedFrame runs perturber against a single paletted GIF frame,
+// remapping each pixel back onto the frame's existing palette so the color
+// table itself is never rewritten.
+func perturbPalettedFrame(frame *image.Paletted, perturber Perturber, path string) {
+	bounds := frame.Bounds()
+	adjusted := applyPerturbation(perturber, frame, path)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			index := uint8(frame.Palette.Index(adjusted.At(x, y)))
+			frame.SetColorIndex(x, y, index)
+		}
+	}
+}
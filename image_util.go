@@ -0,0 +1,19 @@
+package main
+
+import "image"
+
+// imageHasAlpha reports whether any pixel in img is not fully opaque.
+// Used to pick a lossy vs lossless WebP encode and to gate the opportunistic
+// PNG/GIF → JPEG flatten, both of which must not silently discard transparency.
+func imageHasAlpha(img image.Image) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, _, a := img.At(x, y).RGBA()
+			if a < 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const journalManifestName = "manifest.jsonl"
+
+// JournalEntry records one overwrite so `undo` can reverse it later.
+type JournalEntry struct {
+	OriginalPath string    `json:"original_path"`
+	NewPath      string    `json:"new_path"`
+	BackupPath   string    `json:"backup_path"`
+	OriginalMD5  string    `json:"original_md5"`
+	NewMD5       string    `json:"new_md5"`
+	Timestamp    time.Time `json:"timestamp"`
+	Strategy     string    `json:"strategy"`
+}
+
+var journalMu sync.Mutex
+
+// appendJournalEntry copies originalPath's pre-overwrite bytes into dir and
+// appends a manifest entry describing the change about to be made. newPath
+// differs from originalPath when --convert-to swapped the file's extension.
+func appendJournalEntry(dir, originalPath, newPath string, original []byte, originalMD5, newMD5, strategy string) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	if newPath != originalPath {
+		if _, err := os.Stat(newPath); err == nil {
+			// An unrelated file already sits at newPath (e.g. a --convert-to
+			// collision). We only ever back up originalPath below, so
+			// overwriting newPath here would be unrecoverable via undo -
+			// refuse instead of journaling a change we can't fully reverse.
+			return fmt.Errorf("refusing to journal %s: %s already exists and would be overwritten without a backup", originalPath, newPath)
+		}
+	}
+
+	backupDir := filepath.Join(dir, "originals")
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		return err
+	}
+
+	backupPath := filepath.Join(backupDir, originalMD5+"_"+filepath.Base(originalPath))
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		if err := os.WriteFile(backupPath, original, 0o644); err != nil {
+			return err
+		}
+	}
+
+	entry := JournalEntry{
+		OriginalPath: originalPath,
+		NewPath:      newPath,
+		BackupPath:   backupPath,
+		OriginalMD5:  originalMD5,
+		NewMD5:       newMD5,
+		Timestamp:    time.Now(),
+		Strategy:     strategy,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f, err := os.OpenFile(filepath.Join(dir, journalManifestName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+// readJournalEntries parses every entry from dir's manifest, in the order
+// they were recorded.
+func readJournalEntries(dir string) ([]JournalEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, journalManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
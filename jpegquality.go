@@ -0,0 +1,183 @@
+package main
+
+import "os"
+
+// zigzagOrder maps a DQT segment's zigzag scan index to its natural
+// (row-major) position in an 8x8 block, per the JPEG spec.
+var zigzagOrder = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// standardLumaQuantTable and standardChromaQuantTable are the IJG/libjpeg
+// base quantization tables (quality 50, Annex K of the JPEG spec), in
+// natural order.
+var standardLumaQuantTable = [64]int{
+	16, 11, 10, 16, 24, 40, 51, 61,
+	12, 12, 14, 19, 26, 58, 60, 55,
+	14, 13, 16, 24, 40, 57, 69, 56,
+	14, 17, 22, 29, 51, 87, 80, 62,
+	18, 22, 37, 56, 68, 109, 103, 77,
+	24, 35, 55, 64, 81, 104, 113, 92,
+	49, 64, 78, 87, 103, 121, 120, 101,
+	72, 92, 95, 98, 112, 100, 103, 99,
+}
+
+var standardChromaQuantTable = [64]int{
+	17, 18, 24, 47, 99, 99, 99, 99,
+	18, 21, 26, 66, 99, 99, 99, 99,
+	24, 26, 56, 99, 99, 99, 99, 99,
+	47, 66, 99, 99, 99, 99, 99, 99,
+	99, 99, 99, 99, 99, 99, 99, 99,
+	99, 99, 99, 99, 99, 99, 99, 99,
+	99, 99, 99, 99, 99, 99, 99, 99,
+	99, 99, 99, 99, 99, 99, 99, 99,
+}
+
+// quantTable holds one DQT table's 64 natural-order entries plus the table
+// id (Tq) it was stored under (0 is conventionally luma, 1 chroma).
+type quantTable struct {
+	id      int
+	entries [64]int
+}
+
+// parseQuantTables scans data for DQT (0xFF 0xDB) marker segments and
+// returns every quantization table found, de-zigzagged into natural order
+// so they line up with the standard tables above.
+func parseQuantTables(data []byte) []quantTable {
+	var tables []quantTable
+
+	for i := 0; i+3 < len(data); i++ {
+		if data[i] != 0xFF || data[i+1] != 0xDB {
+			continue
+		}
+
+		segLen := int(data[i+2])<<8 | int(data[i+3])
+		end := i + 2 + segLen
+		if segLen < 2 || end > len(data) {
+			continue
+		}
+
+		pos := i + 4
+		for pos < end {
+			precisionAndID := data[pos]
+			precision := precisionAndID >> 4
+			id := int(precisionAndID & 0x0F)
+			pos++
+
+			var raw [64]int
+			if precision == 0 {
+				if pos+64 > end {
+					break
+				}
+				for k := 0; k < 64; k++ {
+					raw[k] = int(data[pos+k])
+				}
+				pos += 64
+			} else {
+				if pos+128 > end {
+					break
+				}
+				for k := 0; k < 64; k++ {
+					raw[k] = int(data[pos+2*k])<<8 | int(data[pos+2*k+1])
+				}
+				pos += 128
+			}
+
+			var natural [64]int
+			for zigzagIndex, naturalIndex := range zigzagOrder {
+				natural[naturalIndex] = raw[zigzagIndex]
+			}
+			tables = append(tables, quantTable{id: id, entries: natural})
+		}
+
+		i = end - 1 // resume scanning right after this segment
+	}
+
+	return tables
+}
+
+// estimateJpegQuality estimates the IJG/libjpeg quality (1-100) a JPEG was
+// encoded at by matching its quantization tables against the standard
+// scaled tables for each candidate quality. Falls back to 75 if the file
+// can't be read, exceeds --max-bytes, or has no DQT segment to inspect.
+func estimateJpegQuality(filePath string) int {
+	if _, err := checkFileSize(filePath); err != nil {
+		return 75
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 75
+	}
+
+	tables := parseQuantTables(data)
+	if len(tables) == 0 {
+		return 75
+	}
+
+	var luma, chroma *quantTable
+	for i := range tables {
+		switch tables[i].id {
+		case 0:
+			luma = &tables[i]
+		case 1:
+			chroma = &tables[i]
+		}
+	}
+
+	switch {
+	case luma != nil:
+		return bestMatchingQuality(luma.entries, standardLumaQuantTable)
+	case chroma != nil:
+		return bestMatchingQuality(chroma.entries, standardChromaQuantTable)
+	default:
+		return bestMatchingQuality(tables[0].entries, standardLumaQuantTable)
+	}
+}
+
+// bestMatchingQuality searches candidate qualities 1..100, reconstructing
+// the IJG-scaled quantization table for each per the standard formula and
+// picking the one closest to observed by sum-of-absolute-differences.
+func bestMatchingQuality(observed, standard [64]int) int {
+	bestQuality := 1
+	bestDiff := -1
+
+	for q := 1; q <= 100; q++ {
+		var scale int
+		if q < 50 {
+			scale = 5000 / q
+		} else {
+			scale = 200 - 2*q
+		}
+
+		diff := 0
+		for i := 0; i < 64; i++ {
+			scaled := (standard[i]*scale + 50) / 100
+			if scaled < 1 {
+				scaled = 1
+			} else if scaled > 255 {
+				scaled = 255
+			}
+
+			delta := scaled - observed[i]
+			if delta < 0 {
+				delta = -delta
+			}
+			diff += delta
+		}
+
+		if bestDiff == -1 || diff < bestDiff {
+			bestDiff = diff
+			bestQuality = q
+		}
+	}
+
+	return bestQuality
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEstimateJpegQuality encodes a synthetic image at several known
+// qualities and checks that estimateJpegQuality recovers each one to within
+// ±2.
+func TestEstimateJpegQuality(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{
+				R: uint8((x * 4) % 256),
+				G: uint8((y * 4) % 256),
+				B: uint8((x + y) % 256),
+				A: 255,
+			})
+		}
+	}
+
+	for _, want := range []int{50, 75, 85, 95} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: want}); err != nil {
+			t.Fatalf("jpeg.Encode(quality=%d): %v", want, err)
+		}
+
+		path := filepath.Join(t.TempDir(), "fixture.jpg")
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		got := estimateJpegQuality(path)
+		if diff := got - want; diff < -2 || diff > 2 {
+			t.Errorf("estimateJpegQuality for quality=%d: got %d, want within ±2", want, got)
+		}
+	}
+}
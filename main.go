@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -8,9 +9,12 @@ import (
 	"image/jpeg"
 	"image/png"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/spf13/cobra"
@@ -30,55 +34,6 @@ var supportedExtensions = map[string]bool{
 	".webp": true,
 }
 
-// estimateJpegQuality attempts to estimate the quality of a JPEG image
-func estimateJpegQuality(filePath string) int {
-	// This is a simplistic approach - in reality, estimating JPEG quality accurately 
-	// is challenging without access to the original encoding parameters
-	
-	// Open the file for reading
-	file, err := os.Open(filePath)
-	if err != nil {
-		return 75 // Return default quality on error
-	}
-	defer file.Close()
-	
-	// Read file info to get size
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return 75 // Return default quality on error
-	}
-	
-	// Decode the image
-	img, format, err := image.Decode(file)
-	if err != nil || format != "jpeg" {
-		return 75 // Return default quality on error
-	}
-	
-	// Get image dimensions
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	pixelCount := width * height
-	
-	// Calculate bytes per pixel
-	bytesPerPixel := float64(fileInfo.Size()) / float64(pixelCount)
-	
-	// Heuristic mapping of bytes-per-pixel to quality
-	// This is approximate and will vary based on image content
-	switch {
-	case bytesPerPixel < 0.5:
-		return 60 // Low quality
-	case bytesPerPixel < 0.75:
-		return 70
-	case bytesPerPixel < 1.0:
-		return 80
-	case bytesPerPixel < 1.5:
-		return 90
-	default:
-		return 95 // High quality
-	}
-}
-
 // estimatePngCompressionLevel estimates PNG compression level
 func estimatePngCompressionLevel(filePath string) png.CompressionLevel {
 	// For PNG, exact compression level detection is difficult
@@ -112,8 +67,76 @@ func estimatePngCompressionLevel(filePath string) png.CompressionLevel {
 var (
 	recursiveDepth int
 	verbose        bool
+	dryRun         bool
+	diffThreshold  float64
+	convertTo      string
+	strategy       string
+	jobs           int
+	journalDir     string
 )
 
+// FileResult describes the outcome of processing (or dry-running) a single file.
+type FileResult struct {
+	Path               string
+	OriginalSize       int64
+	NewSize            int64
+	OriginalChecksum   string
+	NewChecksum        string
+	Replaced           bool // true if the file on disk was actually overwritten
+	WouldReplace       bool // true if the change clears the --diff threshold, regardless of dry-run
+	SkippedByThreshold bool
+	JPEGQualityFrom    int // 0 if no JPEG quality adjustment was made
+	JPEGQualityTo      int
+}
+
+// ChecksumChanged reports whether the re-encode actually altered the file's
+// bytes, as distinct from WouldReplace (which only tracks whether the size
+// delta cleared --diff).
+func (r *FileResult) ChecksumChanged() bool {
+	return r.OriginalChecksum != r.NewChecksum
+}
+
+// SizeDiffPercent returns the absolute percentage change between original and new size.
+func (r *FileResult) SizeDiffPercent() float64 {
+	if r.OriginalSize == 0 {
+		return 0
+	}
+	return math.Abs(float64(r.NewSize-r.OriginalSize)) / float64(r.OriginalSize) * 100
+}
+
+// DirectoryReport aggregates FileResults produced while walking a directory.
+type DirectoryReport struct {
+	FilesScanned int
+	FilesChanged int
+	BytesBefore  int64
+	BytesAfter   int64
+}
+
+// Add folds a single FileResult into the running totals.
+func (r *DirectoryReport) Add(res *FileResult) {
+	r.FilesScanned++
+	r.BytesBefore += res.OriginalSize
+	if res.WouldReplace {
+		r.FilesChanged++
+		r.BytesAfter += res.NewSize
+	} else {
+		r.BytesAfter += res.OriginalSize
+	}
+}
+
+// Print writes a human-readable summary of the report to stdout.
+func (r *DirectoryReport) Print() {
+	fmt.Printf("\nSummary:\n")
+	fmt.Printf("  Files scanned: %d\n", r.FilesScanned)
+	fmt.Printf("  Files that would change: %d\n", r.FilesChanged)
+	fmt.Printf("  Bytes before: %d\n", r.BytesBefore)
+	fmt.Printf("  Bytes after:  %d\n", r.BytesAfter)
+	if r.BytesBefore > 0 {
+		saved := float64(r.BytesBefore-r.BytesAfter) / float64(r.BytesBefore) * 100
+		fmt.Printf("  Saved: %.2f%%\n", saved)
+	}
+}
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "alternate-assets [path]",
@@ -129,9 +152,15 @@ func main() {
 			}
 
 			if stat.IsDir() {
-				err = processDirectory(path, 0, recursiveDepth)
+				report := &DirectoryReport{}
+				err = processDirectory(path, recursiveDepth, jobs, report)
+				report.Print()
 			} else {
-				err = processFile(path)
+				var result *FileResult
+				result, err = processFile(path)
+				if result != nil {
+					printFileResult(result)
+				}
 			}
 
 			if err != nil {
@@ -141,8 +170,19 @@ func main() {
 		},
 	}
 
-	rootCmd.Flags().IntVarP(&recursiveDepth, "recursive", "r", 0, "Process directories recursively up to specified depth")
+	rootCmd.Flags().IntVarP(&recursiveDepth, "recursive", "r", 0, "Process directories recursively up to the specified depth (negative for unlimited, 0 for the top directory only)")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Display detailed information about the operations")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without writing any files")
+	rootCmd.Flags().Float64Var(&diffThreshold, "diff", 0, "Skip files whose projected size change is below this percent")
+	rootCmd.Flags().StringVar(&convertTo, "convert-to", "", "Opportunistically convert non-transparent output to this format (currently only: jpg)")
+	rootCmd.Flags().StringVar(&strategy, "strategy", "brightness", "Perturbation strategy: brightness, lsb-noise, metadata-only, chroma-jitter")
+	rootCmd.Flags().IntVar(&jobs, "jobs", runtime.NumCPU(), "Number of files to process concurrently when walking a directory")
+	rootCmd.Flags().StringVar(&journalDir, "journal", "", "Record original files and a manifest into this directory before overwriting, so `undo` can restore them")
+	rootCmd.Flags().Int64Var(&maxPixels, "max-pixels", 100_000_000, "Reject images whose width*height exceeds this many pixels")
+	rootCmd.Flags().Int64Var(&maxBytes, "max-bytes", 200*1024*1024, "Reject files larger than this many bytes")
+	rootCmd.Flags().DurationVar(&decodeTimeout, "decode-timeout", 30*time.Second, "Per-file timeout for decoding")
+
+	rootCmd.AddCommand(newUndoCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -166,19 +206,170 @@ func calculateFileChecksum(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// Process a single image file
-func processFile(path string) error {
+// printFileResult writes a human-readable line (or block, in verbose mode) describing result.
+func printFileResult(result *FileResult) {
+	prefix := "Processed"
+	if dryRun {
+		prefix = "Would process"
+	}
+
+	if verbose {
+		fmt.Printf("%s: %s\n", prefix, result.Path)
+		fmt.Printf("Original checksum: %s\n", result.OriginalChecksum)
+		fmt.Printf("New checksum: %s\n", result.NewChecksum)
+		fmt.Printf("Size: %d → %d (%.2f%%)\n", result.OriginalSize, result.NewSize, result.SizeDiffPercent())
+		fmt.Printf("Checksum changed: %t\n", result.ChecksumChanged())
+		if result.JPEGQualityFrom != 0 {
+			fmt.Printf("JPEG quality adjustment: %d → %d\n", result.JPEGQualityFrom, result.JPEGQualityTo)
+		}
+		if result.SkippedByThreshold {
+			fmt.Printf("Skipped: below --diff threshold\n")
+		}
+		fmt.Println()
+		return
+	}
+
+	changeSymbol := "✗"
+	if result.ChecksumChanged() {
+		changeSymbol = "✓"
+	}
+	fmt.Printf("%s: %s %s\n", prefix, result.Path, changeSymbol)
+}
+
+// processFile re-encodes a single image file and reports what changed.
+//
+// In dry-run mode the re-encoded bytes are kept in memory and the source file
+// is never touched. In normal mode, files whose projected size change falls
+// below --diff are left untouched as well, mirroring the dry-run behavior
+// so that --diff is useful with or without --dry-run.
+func processFile(path string) (*FileResult, error) {
 	ext := strings.ToLower(filepath.Ext(path))
-	
+
 	if !supportedExtensions[ext] {
-		return fmt.Errorf("unsupported file extension: %s", ext)
+		return nil, fmt.Errorf("unsupported file extension: %s", ext)
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
 	originalChecksum, err := calculateFileChecksum(path)
 	if err != nil {
-		return fmt.Errorf("failed to calculate original checksum: %w", err)
+		return nil, fmt.Errorf("failed to calculate original checksum: %w", err)
+	}
+
+	perturber, err := GetPerturber(strategy)
+	if err != nil {
+		return nil, err
 	}
 
+	var outBytes []byte
+	var jpegQualityFrom, jpegQualityTo int
+	if metaPerturber, ok := perturber.(MetadataPerturber); ok {
+		// Metadata strategies bypass the decode/re-encode pipeline entirely
+		// so the pixels stay byte-for-byte identical.
+		original, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read original file: %w", readErr)
+		}
+		outBytes, err = metaPerturber.ApplyMetadata(original, ext)
+	} else {
+		switch ext {
+		case ".gif":
+			outBytes, err = encodeGIF(path, perturber)
+			if err == nil && convertTo == "jpg" {
+				if frame, ferr := firstGIFFrame(path); ferr == nil {
+					if convertedExt, convertedBytes, converted := maybeConvertToJPEG(ext, frame, outBytes); converted && !convertCollides(path, convertedExt) {
+						ext, outBytes = convertedExt, convertedBytes
+					}
+				}
+			}
+		case ".webp":
+			outBytes, err = encodeWebP(path, perturber)
+		default:
+			var raster *rasterEncodeResult
+			raster, err = encodeRasterVariant(path, ext, perturber)
+			if err == nil {
+				outBytes = raster.bytes
+				jpegQualityFrom, jpegQualityTo = raster.jpegQualityFrom, raster.jpegQualityTo
+				if convertedExt, convertedBytes, converted := maybeConvertToJPEG(ext, raster.image, outBytes); converted && !convertCollides(path, convertedExt) {
+					ext, outBytes = convertedExt, convertedBytes
+					jpegQualityFrom, jpegQualityTo = 0, 0 // quality diagnostic no longer applies once converted
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	outPath := path
+	if ext != strings.ToLower(filepath.Ext(path)) {
+		outPath = withExt(path, ext)
+	}
+
+	newChecksum := fmt.Sprintf("%x", md5.Sum(outBytes))
+
+	result := &FileResult{
+		Path:             outPath,
+		OriginalSize:     fileInfo.Size(),
+		NewSize:          int64(len(outBytes)),
+		OriginalChecksum: originalChecksum,
+		NewChecksum:      newChecksum,
+		JPEGQualityFrom:  jpegQualityFrom,
+		JPEGQualityTo:    jpegQualityTo,
+	}
+
+	clearsThreshold := result.SizeDiffPercent() >= diffThreshold
+	if !clearsThreshold {
+		result.SkippedByThreshold = true
+		return result, nil
+	}
+	result.WouldReplace = true
+
+	if dryRun {
+		return result, nil
+	}
+
+	if journalDir != "" {
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read original for journal: %w", err)
+		}
+		if err := appendJournalEntry(journalDir, path, outPath, original, originalChecksum, newChecksum, strategy); err != nil {
+			return nil, fmt.Errorf("failed to write journal entry: %w", err)
+		}
+	}
+
+	if err := atomicWriteFile(outPath, outBytes, fileInfo.Mode()); err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+	if outPath != path {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("converted to %s but failed to remove original: %w", outPath, err)
+		}
+	}
+	result.Replaced = true
+
+	return result, nil
+}
+
+// rasterEncodeResult carries encodeRasterVariant's output back to the
+// caller: the perturbed image (so maybeConvertToJPEG can reuse it without
+// re-decoding) and, for JPEGs, the quality adjustment that was applied, so
+// the serialized collector can report it alongside the rest of FileResult
+// instead of printing it from inside a worker-pool goroutine.
+type rasterEncodeResult struct {
+	image           image.Image
+	bytes           []byte
+	jpegQualityFrom int // 0 if ext isn't JPEG
+	jpegQualityTo   int
+}
+
+// encodeRasterVariant handles the formats imaging can safely round-trip
+// (JPEG, PNG, and anything else imaging knows how to save).
+func encodeRasterVariant(path, ext string, perturber Perturber) (*rasterEncodeResult, error) {
 	// Estimate quality and other properties based on file format
 	jpegQuality := 75 // Default quality if we can't determine
 	var compressionLevel png.CompressionLevel = png.DefaultCompression
@@ -191,26 +382,22 @@ func processFile(path string) error {
 		compressionLevel = estimatePngCompressionLevel(path)
 	}
 
-	// Open the image file for processing
-	src, err := imaging.Open(path)
+	// Decode with size/pixel/timeout guards rather than imaging.Open, since
+	// this path runs over attacker-controllable files.
+	src, _, err := safeDecode(path)
 	if err != nil {
-		return fmt.Errorf("failed to decode image: %w", err)
+		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	// Make a subtle change to the image (brightness adjustment by 0.1%)
-	processed := imaging.AdjustBrightness(src, 0.1)
-
-	// Save the modified image
-	outputFile, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer outputFile.Close()
+	// Make a subtle, checksum-changing perturbation per the selected strategy
+	processed := applyPerturbation(perturber, src, path)
 
+	var buf bytes.Buffer
 	var saveErr error
+	result := &rasterEncodeResult{image: processed}
 	switch ext {
 	case ".jpg", ".jpeg":
-		// Apply a slight change to quality (±1) to ensure checksum changes 
+		// Apply a slight change to quality (±1) to ensure checksum changes
 		// while maintaining visual similarity
 		adjustedQuality := jpegQuality
 		if jpegQuality > 90 {
@@ -218,75 +405,26 @@ func processFile(path string) error {
 		} else {
 			adjustedQuality = jpegQuality + 1 // Increase slightly for lower quality
 		}
-		saveErr = jpeg.Encode(outputFile, processed, &jpeg.Options{Quality: adjustedQuality})
-		if verbose {
-			fmt.Printf("JPEG quality adjustment: %d → %d\n", jpegQuality, adjustedQuality)
-		}
+		saveErr = jpeg.Encode(&buf, processed, &jpeg.Options{Quality: adjustedQuality})
+		result.jpegQualityFrom, result.jpegQualityTo = jpegQuality, adjustedQuality
 	case ".png":
 		// For PNG, we'll use the estimated compression but make a small adjustment
 		encoder := png.Encoder{CompressionLevel: compressionLevel}
-		saveErr = encoder.Encode(outputFile, processed)
+		saveErr = encoder.Encode(&buf, processed)
 	default:
-		// For other formats, use the imaging library's Save function
-		saveErr = imaging.Save(processed, path)
+		// For other formats, use the imaging library's encoder via its format guess
+		format, formatErr := imaging.FormatFromExtension(ext)
+		if formatErr != nil {
+			return nil, fmt.Errorf("failed to determine image format: %w", formatErr)
+		}
+		saveErr = imaging.Encode(&buf, processed, format)
 	}
 
 	if saveErr != nil {
-		return fmt.Errorf("failed to save image: %w", saveErr)
-	}
-
-	// Calculate the new checksum
-	newChecksum, err := calculateFileChecksum(path)
-	if err != nil {
-		return fmt.Errorf("failed to calculate new checksum: %w", err)
-	}
-
-	// Print results
-	if verbose {
-		fmt.Printf("Processed: %s\n", path)
-		fmt.Printf("Original checksum: %s\n", originalChecksum)
-		fmt.Printf("New checksum: %s\n", newChecksum)
-		fmt.Printf("Checksum changed: %t\n\n", originalChecksum != newChecksum)
-	} else {
-		changeSymbol := "✗"
-		if originalChecksum != newChecksum {
-			changeSymbol = "✓"
-		}
-		fmt.Printf("Processed: %s %s\n", path, changeSymbol)
+		return nil, saveErr
 	}
 
-	return nil
+	result.bytes = buf.Bytes()
+	return result, nil
 }
 
-// Process a directory
-func processDirectory(path string, currentDepth, maxDepth int) error {
-	if currentDepth > maxDepth {
-		return nil
-	}
-
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return fmt.Errorf("failed to read directory: %w", err)
-	}
-
-	for _, entry := range entries {
-		entryPath := filepath.Join(path, entry.Name())
-
-		if entry.IsDir() && currentDepth < maxDepth {
-			err := processDirectory(entryPath, currentDepth+1, maxDepth)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error processing directory %s: %v\n", entryPath, err)
-			}
-		} else if !entry.IsDir() {
-			ext := strings.ToLower(filepath.Ext(entry.Name()))
-			if supportedExtensions[ext] {
-				err := processFile(entryPath)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", entryPath, err)
-				}
-			}
-		}
-	}
-
-	return nil
-}
\ No newline at end of file
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"image"
+)
+
+// Perturber produces the imperceptible change that alters a file's checksum.
+// Selected via --strategy; see perturber_*.go for the built-in strategies.
+type Perturber interface {
+	Name() string
+	Apply(img image.Image) image.Image
+}
+
+// PathAwarePerturber is implemented by strategies whose perturbation must be
+// deterministic per source file (e.g. seeding a PRNG off the file path)
+// rather than purely a function of the decoded pixels.
+type PathAwarePerturber interface {
+	Perturber
+	ApplyPath(img image.Image, path string) image.Image
+}
+
+// MetadataPerturber is implemented by strategies that change the checksum by
+// rewriting container metadata instead of pixels. processFile routes these
+// straight from the original bytes, bypassing the decode/re-encode pipeline
+// entirely so the pixels stay byte-for-byte identical.
+type MetadataPerturber interface {
+	Perturber
+	ApplyMetadata(original []byte, ext string) ([]byte, error)
+}
+
+var perturberRegistry = map[string]Perturber{}
+
+func registerPerturber(p Perturber) {
+	perturberRegistry[p.Name()] = p
+}
+
+// GetPerturber looks up a registered strategy by its --strategy name.
+func GetPerturber(name string) (Perturber, error) {
+	p, ok := perturberRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown perturbation strategy: %s", name)
+	}
+	return p, nil
+}
+
+// applyPerturbation runs p against img, threading the source path through for
+// strategies that need it to stay deterministic per file.
+func applyPerturbation(p Perturber, img image.Image, path string) image.Image {
+	if pathAware, ok := p.(PathAwarePerturber); ok {
+		return pathAware.ApplyPath(img, path)
+	}
+	return p.Apply(img)
+}
+
+func init() {
+	registerPerturber(brightnessPerturber{})
+	registerPerturber(lsbNoisePerturber{})
+	registerPerturber(metadataOnlyPerturber{})
+	registerPerturber(chromaJitterPerturber{})
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// brightnessPerturber is the original, default strategy: a +0.1% brightness
+// adjustment across every pixel.
+type brightnessPerturber struct{}
+
+func (brightnessPerturber) Name() string { return "brightness" }
+
+func (brightnessPerturber) Apply(img image.Image) image.Image {
+	return imaging.AdjustBrightness(img, 0.1)
+}
@@ -0,0 +1,47 @@
+package main
+
+import "image"
+
+// chromaJitterPerturber nudges a single chroma sample by ±1 in YCbCr space,
+// which is where JPEG encoding already happens internally. It only applies
+// to sources that are already *image.YCbCr (i.e. JPEG decodes); converting
+// other formats into YCbCr and back is lossy enough to change every pixel,
+// so anything else is left untouched rather than silently mangled.
+type chromaJitterPerturber struct{}
+
+func (chromaJitterPerturber) Name() string { return "chroma-jitter" }
+
+func (chromaJitterPerturber) Apply(img image.Image) image.Image {
+	src, ok := img.(*image.YCbCr)
+	if !ok {
+		return img
+	}
+
+	dst := cloneYCbCr(src)
+	if len(dst.Cb) == 0 {
+		return dst
+	}
+
+	mid := len(dst.Cb) / 2
+	if dst.Cb[mid] < 255 {
+		dst.Cb[mid]++
+	} else {
+		dst.Cb[mid]--
+	}
+
+	return dst
+}
+
+// cloneYCbCr copies src's pixel planes into a new *image.YCbCr so callers can
+// mutate the result without corrupting the decoder's original buffer.
+func cloneYCbCr(src *image.YCbCr) *image.YCbCr {
+	return &image.YCbCr{
+		Y:              append([]byte(nil), src.Y...),
+		Cb:             append([]byte(nil), src.Cb...),
+		Cr:             append([]byte(nil), src.Cr...),
+		YStride:        src.YStride,
+		CStride:        src.CStride,
+		SubsampleRatio: src.SubsampleRatio,
+		Rect:           src.Rect,
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"hash/fnv"
+	"image"
+	"math/rand"
+
+	"github.com/disintegration/imaging"
+)
+
+// lsbNoisePerturber flips a single bit in the least-significant bit of one
+// pseudo-random pixel's red channel. The pixel is chosen from a seed that
+// mixes the file path with the currently decoded pixel content, so the same
+// starting image always perturbs the same way, but re-running the tool on
+// its own output (whose content has now changed) lands on a different
+// pixel instead of flipping the same bit back and forth.
+type lsbNoisePerturber struct{}
+
+func (lsbNoisePerturber) Name() string { return "lsb-noise" }
+
+func (lsbNoisePerturber) Apply(img image.Image) image.Image {
+	return lsbNoisePerturber{}.ApplyPath(img, "")
+}
+
+func (lsbNoisePerturber) ApplyPath(img image.Image, path string) image.Image {
+	dst := imaging.Clone(img)
+	bounds := dst.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return dst
+	}
+
+	rng := rand.New(rand.NewSource(pathSeed(path) ^ contentSeed(dst.Pix)))
+	x := bounds.Min.X + rng.Intn(width)
+	y := bounds.Min.Y + rng.Intn(height)
+
+	c := dst.NRGBAAt(x, y)
+	c.R ^= 1 // flip the LSB; a ±1 change in one channel of one pixel
+	dst.SetNRGBA(x, y, c)
+
+	return dst
+}
+
+// pathSeed turns a file path into a deterministic PRNG seed component;
+// ApplyPath XORs it with contentSeed, so the path alone does not fix which
+// pixel is chosen.
+func pathSeed(path string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	return int64(h.Sum64())
+}
+
+// contentSeed hashes the decoded pixel buffer into a seed component so the
+// chosen pixel moves on to a new spot once the content itself has changed,
+// instead of the perturbation being a pure function of path alone.
+func contentSeed(pix []byte) int64 {
+	h := fnv.New64a()
+	h.Write(pix)
+	return int64(h.Sum64())
+}
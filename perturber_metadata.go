@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"time"
+)
+
+// metadataOnlyPerturber leaves every pixel untouched and instead injects a
+// benign text comment into the container format: a PNG tEXt chunk or a JPEG
+// COM segment. Each run adds a new comment; comments added by earlier runs
+// are kept, not replaced. It changes the checksum without changing a single
+// decoded pixel, for users (e.g. photographers regenerating CDN URLs) who
+// need pixel-perfect output.
+type metadataOnlyPerturber struct{}
+
+func (metadataOnlyPerturber) Name() string { return "metadata-only" }
+
+// Apply is never meant to run the normal decode/re-encode pipeline for this
+// strategy (processFile routes MetadataPerturbers around it entirely), but
+// it's implemented as an identity transform to satisfy the Perturber
+// interface for any caller that invokes it directly.
+func (metadataOnlyPerturber) Apply(img image.Image) image.Image {
+	return img
+}
+
+func (metadataOnlyPerturber) ApplyMetadata(original []byte, ext string) ([]byte, error) {
+	comment := fmt.Sprintf("alternate-assets:%d", time.Now().UnixNano())
+
+	switch ext {
+	case ".png":
+		return injectPNGTextChunk(original, "Comment", comment)
+	case ".jpg", ".jpeg":
+		return injectJPEGComment(original, comment)
+	default:
+		return nil, fmt.Errorf("metadata-only strategy does not support %s", ext)
+	}
+}
+
+// injectPNGTextChunk inserts a tEXt chunk (keyword\0text) immediately before
+// the IEND chunk of a PNG byte stream.
+func injectPNGTextChunk(data []byte, keyword, text string) ([]byte, error) {
+	iend := bytes.LastIndex(data, []byte("IEND"))
+	if iend < 4 {
+		return nil, fmt.Errorf("not a valid PNG (missing IEND chunk)")
+	}
+	insertAt := iend - 4 // start of IEND's 4-byte length field
+
+	payload := append(append([]byte(keyword), 0), []byte(text)...)
+	chunk := buildPNGChunk("tEXt", payload)
+
+	out := make([]byte, 0, len(data)+len(chunk))
+	out = append(out, data[:insertAt]...)
+	out = append(out, chunk...)
+	out = append(out, data[insertAt:]...)
+	return out, nil
+}
+
+// buildPNGChunk assembles a length-prefixed, CRC-suffixed PNG chunk.
+func buildPNGChunk(chunkType string, payload []byte) []byte {
+	chunk := make([]byte, 0, 12+len(payload))
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(payload)))
+	chunk = append(chunk, length...)
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+
+	crc := crc32.ChecksumIEEE(append([]byte(chunkType), payload...))
+	crcBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(crcBytes, crc)
+	chunk = append(chunk, crcBytes...)
+
+	return chunk
+}
+
+// injectJPEGComment inserts a COM segment (marker 0xFFFE) right
+// after the SOI marker of a JPEG byte stream.
+func injectJPEGComment(data []byte, comment string) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("not a valid JPEG (missing SOI marker)")
+	}
+
+	payload := []byte(comment)
+	segmentLen := len(payload) + 2 // length field includes itself
+	if segmentLen > 0xFFFF {
+		return nil, fmt.Errorf("comment too long for a single COM segment")
+	}
+
+	segment := make([]byte, 0, 4+len(payload))
+	segment = append(segment, 0xFF, 0xFE)
+	lengthBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(lengthBytes, uint16(segmentLen))
+	segment = append(segment, lengthBytes...)
+	segment = append(segment, payload...)
+
+	out := make([]byte, 0, len(data)+len(segment))
+	out = append(out, data[:2]...)
+	out = append(out, segment...)
+	out = append(out, data[2:]...)
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"time"
+)
+
+var (
+	maxPixels     int64
+	maxBytes      int64
+	decodeTimeout time.Duration
+)
+
+// checkFileSize stats path and rejects it if it exceeds --max-bytes.
+func checkFileSize(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes > 0 && info.Size() > maxBytes {
+		return nil, fmt.Errorf("file size %d bytes exceeds --max-bytes limit of %d", info.Size(), maxBytes)
+	}
+	return info, nil
+}
+
+// checkPixelBudget rejects dimensions whose product exceeds --max-pixels,
+// catching huge-canvas decode bombs before a full pixel buffer is allocated.
+func checkPixelBudget(width, height int) error {
+	if maxPixels > 0 && int64(width)*int64(height) > maxPixels {
+		return fmt.Errorf("image dimensions %dx%d exceed --max-pixels limit of %d", width, height, maxPixels)
+	}
+	return nil
+}
+
+// safeDecode decodes an image from path with three layers of protection
+// against hostile input: a file-size cap, a DecodeConfig dimension check
+// before the full pixel buffer is allocated, and a timeout plus panic
+// recovery around the actual decode, so a malformed file can only ever cost
+// one file's worth of time instead of hanging or crashing the whole run.
+func safeDecode(path string) (image.Image, string, error) {
+	if _, err := checkFileSize(path); err != nil {
+		return nil, "", err
+	}
+
+	cfgFile, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, _, err := image.DecodeConfig(cfgFile)
+	cfgFile.Close()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image header: %w", err)
+	}
+	if err := checkPixelBudget(cfg.Width, cfg.Height); err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), decodeTimeout)
+	defer cancel()
+
+	type result struct {
+		img    image.Image
+		format string
+		err    error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("panic while decoding image: %v", r)}
+			}
+		}()
+
+		f, err := os.Open(path)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer f.Close()
+
+		img, format, err := image.Decode(f)
+		done <- result{img: img, format: format, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, "", res.err
+		}
+		return res.img, res.format, nil
+	case <-ctx.Done():
+		return nil, "", fmt.Errorf("decoding %s timed out after %s", path, decodeTimeout)
+	}
+}
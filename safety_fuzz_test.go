@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// FuzzSafeDecode feeds arbitrary bytes into safeDecode and asserts it never
+// panics out of the test and always returns within five seconds while its
+// decode timeout is set to one second, regardless of how malformed the input
+// is.
+func FuzzSafeDecode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'})
+	f.Add([]byte{0xff, 0xd8, 0xff, 0xe0})
+	f.Add([]byte("GIF89a"))
+	f.Add([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		maxPixels = 0
+		maxBytes = 0
+		decodeTimeout = time.Second
+
+		path := filepath.Join(t.TempDir(), "fuzz-input")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			safeDecode(path)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("safeDecode did not return within its timeout")
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// newUndoCommand builds the `alternate-assets undo <journal-dir>` subcommand.
+func newUndoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo <journal-dir>",
+		Short: "Restore files recorded by --journal to their pre-processing state",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUndo(args[0])
+		},
+	}
+}
+
+// runUndo replays a journal's manifest in reverse. Each file's current
+// checksum is verified against the manifest's recorded post-overwrite
+// checksum before it's restored, so a tree that's been modified again since
+// journaling can't be silently clobbered.
+func runUndo(dir string) error {
+	entries, err := readJournalEntries(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		currentChecksum, err := calculateFileChecksum(entry.NewPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", entry.NewPath, err)
+			continue
+		}
+		if currentChecksum != entry.NewMD5 {
+			fmt.Fprintf(os.Stderr, "Skipping %s: on-disk checksum %s does not match journaled %s (modified since journaling)\n",
+				entry.NewPath, currentChecksum, entry.NewMD5)
+			continue
+		}
+
+		original, err := os.ReadFile(entry.BackupPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: failed to read backup: %v\n", entry.OriginalPath, err)
+			continue
+		}
+
+		mode := os.FileMode(0o644)
+		if info, err := os.Stat(entry.NewPath); err == nil {
+			mode = info.Mode()
+		}
+
+		if err := atomicWriteFile(entry.OriginalPath, original, mode); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to restore %s: %v\n", entry.OriginalPath, err)
+			continue
+		}
+
+		if entry.NewPath != entry.OriginalPath {
+			if err := os.Remove(entry.NewPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Restored %s but failed to remove converted file %s: %v\n",
+					entry.OriginalPath, entry.NewPath, err)
+				continue
+			}
+		}
+
+		fmt.Printf("Restored: %s\n", entry.OriginalPath)
+	}
+
+	return nil
+}
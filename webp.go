@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+
+	"golang.org/x/image/webp"
+)
+
+// encodeWebP decodes a WebP image, applies the selected perturbation
+// strategy and re-encodes it, choosing lossless encoding when the source has
+// an alpha channel and lossy otherwise. The actual encoder is selected at
+// build time via encodeWebPBytes (see webp_encode.go / webp_encode_stub.go)
+// since the maintained WebP encoders are cgo-backed.
+func encodeWebP(path string, perturber Perturber) ([]byte, error) {
+	if _, err := checkFileSize(path); err != nil {
+		return nil, err
+	}
+	if err := checkWebPPixelBudget(path); err != nil {
+		return nil, err
+	}
+
+	src, err := decodeWebPGuarded(path)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := applyPerturbation(perturber, src, path)
+	lossless := imageHasAlpha(src)
+
+	return encodeWebPBytes(processed, lossless)
+}
+
+// checkWebPPixelBudget reads just path's WebP header via webp.DecodeConfig so
+// the pixel budget can be enforced before webp.Decode allocates the full
+// pixel buffer.
+func checkWebPPixelBudget(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, err := webp.DecodeConfig(f)
+	if err != nil {
+		return fmt.Errorf("failed to read webp header: %w", err)
+	}
+	return checkPixelBudget(cfg.Width, cfg.Height)
+}
+
+// decodeWebPGuarded decodes path's WebP data behind a timeout and panic
+// recovery, mirroring safeDecode's protections for x/image/webp's separate
+// decode entry point.
+func decodeWebPGuarded(path string) (image.Image, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), decodeTimeout)
+	defer cancel()
+
+	type result struct {
+		img image.Image
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("panic while decoding webp: %v", r)}
+			}
+		}()
+
+		file, err := os.Open(path)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer file.Close()
+
+		img, err := webp.Decode(file)
+		done <- result{img: img, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.img, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("decoding %s timed out after %s", path, decodeTimeout)
+	}
+}
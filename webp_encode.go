@@ -0,0 +1,21 @@
+//go:build webp
+
+package main
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/chai2010/webp"
+)
+
+// encodeWebPBytes encodes img as WebP using chai2010/webp's cgo-backed
+// libwebp bindings. Only linked in when built with `-tags webp`.
+func encodeWebPBytes(img image.Image, lossless bool) ([]byte, error) {
+	var buf bytes.Buffer
+	opts := &webp.Options{Lossless: lossless, Quality: 90}
+	if err := webp.Encode(&buf, img, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
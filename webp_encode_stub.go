@@ -0,0 +1,15 @@
+//go:build !webp
+
+package main
+
+import (
+	"fmt"
+	"image"
+)
+
+// encodeWebPBytes is the default build's stand-in for the real encoder.
+// WebP re-encoding needs a cgo-backed libwebp, so it's opt-in via
+// `go build -tags webp` rather than a transitive dependency of every build.
+func encodeWebPBytes(_ image.Image, _ bool) ([]byte, error) {
+	return nil, fmt.Errorf("webp encoding support not built into this binary (rebuild with -tags webp)")
+}